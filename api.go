@@ -0,0 +1,269 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultAPIPageSize caps how many rows apiList returns when the caller
+// doesn't pass ?limit=.
+const defaultAPIPageSize = 100
+
+// wantsJSON reports whether r should be served JSON instead of HTML,
+// consulting Admin.Negotiator if set and falling back to the Accept header.
+func (a *Admin) wantsJSON(r *http.Request) bool {
+	if a.Negotiator != nil {
+		return a.Negotiator(r)
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
+
+// apiFields returns the fields exposed over REST for m, filtered by role
+// and, for list responses, projected down to the columns marked `list`.
+func apiFields(m *model, role string, listOnly bool) []Field {
+	fields := []Field{}
+	for _, f := range m.fields {
+		attrs := f.Attrs()
+		if listOnly && !attrs.list {
+			continue
+		}
+		if !attrs.canRead(role) {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func marshalRow(fields []Field, values []interface{}) map[string]interface{} {
+	obj := map[string]interface{}{}
+	for i, f := range fields {
+		obj[f.Attrs().columnName] = f.Marshal(values[i])
+	}
+	return obj
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// modelForAPI resolves {slug} and checks that role may view the model,
+// writing the appropriate error response and returning ok=false otherwise.
+func (a *Admin) modelForAPI(w http.ResponseWriter, r *http.Request, s *session) (m *model, ok bool) {
+	m, found := a.models[mux.Vars(r)["slug"]]
+	if !found {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if !m.canViewModel(s.Role) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return nil, false
+	}
+	return m, true
+}
+
+// apiList handles GET /api/model/{slug}/. It supports ?limit=&offset=
+// pagination and ?<column>=<val> filtering on columns tagged `filter`.
+func (a *Admin) apiList(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForAPI(w, r, s)
+	if !ok {
+		return
+	}
+
+	fields := apiFields(m, s.Role, true)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Attrs().columnName
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName))
+
+	where := []string{}
+	args := []interface{}{}
+	for _, f := range m.fields {
+		if !f.Attrs().filter {
+			continue
+		}
+		if val := r.URL.Query().Get(f.Attrs().columnName); len(val) > 0 {
+			where = append(where, fmt.Sprintf("%s = %s", a.Driver.Quote(f.Attrs().columnName), a.Driver.Placeholder(len(args)+1)))
+			args = append(args, val)
+		}
+	}
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	limit := defaultAPIPageSize
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	offset := 0
+	if v, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && v >= 0 {
+		offset = v
+	}
+	query = a.Driver.Paginate(query, limit, offset)
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		dest := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		results = append(results, marshalRow(fields, dest))
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// apiGet handles GET /api/model/{slug}/{id}/.
+func (a *Admin) apiGet(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForAPI(w, r, s)
+	if !ok {
+		return
+	}
+
+	fields := apiFields(m, s.Role, false)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Attrs().columnName
+	}
+
+	row := a.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1)), mux.Vars(r)["id"])
+	dest := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+	if err := row.Scan(ptrs...); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, marshalRow(fields, dest))
+}
+
+// apiWrite decodes the request's JSON body into column/value pairs for
+// fields role may write. A submitted column of a field role may not write
+// is rejected with 403; keys that name no field are ignored.
+func (a *Admin) apiWrite(w http.ResponseWriter, r *http.Request, m *model, role string) (columns []string, values []interface{}, ok bool) {
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	for _, f := range m.fields {
+		attrs := f.Attrs()
+		val, present := body[attrs.columnName]
+		if !present {
+			continue
+		}
+		if !attrs.canWrite(role) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("role %q cannot write %q", role, attrs.columnName)})
+			return nil, nil, false
+		}
+		columns = append(columns, attrs.columnName)
+		values = append(values, val)
+	}
+
+	return columns, values, true
+}
+
+// apiCreate handles POST /api/model/{slug}/.
+func (a *Admin) apiCreate(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForAPI(w, r, s)
+	if !ok {
+		return
+	}
+	if !m.canEditModel(s.Role) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return
+	}
+
+	columns, values, ok := a.apiWrite(w, r, m, s.Role)
+	if !ok {
+		return
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", a.Driver.Quote(m.tableName), strings.Join(a.quoteColumns(columns), ", "), strings.Join(a.placeholders(len(columns)), ", "))
+	res, err := a.db.Exec(query, values...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	id, _ := res.LastInsertId()
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"id": id})
+}
+
+// apiUpdate handles PUT /api/model/{slug}/{id}/.
+func (a *Admin) apiUpdate(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForAPI(w, r, s)
+	if !ok {
+		return
+	}
+	if !m.canEditModel(s.Role) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return
+	}
+
+	columns, values, ok := a.apiWrite(w, r, m, s.Role)
+	if !ok {
+		return
+	}
+
+	ph := a.placeholders(len(columns) + 1)
+	setClauses := make([]string, len(columns))
+	for i, c := range columns {
+		setClauses[i] = fmt.Sprintf("%s = %s", a.Driver.Quote(c), ph[i])
+	}
+	values = append(values, mux.Vars(r)["id"])
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", a.Driver.Quote(m.tableName), strings.Join(setClauses, ", "), a.Driver.Quote("id"), ph[len(columns)])
+	if _, err := a.db.Exec(query, values...); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// apiDelete handles DELETE /api/model/{slug}/{id}/.
+func (a *Admin) apiDelete(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForAPI(w, r, s)
+	if !ok {
+		return
+	}
+	if !m.canEditModel(s.Role) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		return
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1))
+	if _, err := a.db.Exec(query, mux.Vars(r)["id"]); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
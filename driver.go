@@ -0,0 +1,208 @@
+package admin
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// Driver isolates the admin from a particular SQL dialect, so query
+// building in handlers.go/api.go/graphql.go never hard-codes sqlite
+// syntax directly.
+type Driver interface {
+	// Open connects to dsn and returns a ready-to-use *sql.DB.
+	Open(dsn string) (*sql.DB, error)
+
+	// Quote wraps ident in the dialect's identifier-quoting characters.
+	Quote(ident string) string
+
+	// Placeholder returns the bound-parameter marker for the n-th
+	// argument (1-indexed) in a query.
+	Placeholder(n int) string
+
+	// ColumnType returns the column type used for f in CREATE/ALTER TABLE.
+	ColumnType(f Field) string
+
+	// CreateTableSQL returns a CREATE TABLE IF NOT EXISTS statement for m.
+	CreateTableSQL(m *model) string
+
+	// AddColumnSQL returns an ALTER TABLE statement adding f's column to m.
+	AddColumnSQL(m *model, f Field) string
+
+	// Paginate appends the dialect's LIMIT/OFFSET clause to query.
+	Paginate(query string, limit, offset int) string
+}
+
+func createTableSQL(d Driver, m *model, idColumn string) string {
+	cols := []string{idColumn}
+	for _, f := range m.fields {
+		cols = append(cols, fmt.Sprintf("%s %s", d.Quote(f.Attrs().columnName), d.ColumnType(f)))
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.Quote(m.tableName), strings.Join(cols, ", "))
+}
+
+// SQLiteDriver is the default Driver, matching the admin's original
+// hard-coded sqlite3 behavior.
+type SQLiteDriver struct{}
+
+func (d *SQLiteDriver) Open(dsn string) (*sql.DB, error) {
+	return sql.Open("sqlite3", dsn)
+}
+
+func (d *SQLiteDriver) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (d *SQLiteDriver) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *SQLiteDriver) ColumnType(f Field) string {
+	switch f.(type) {
+	case *IntField:
+		return "INTEGER"
+	case *FloatField:
+		return "REAL"
+	case *TimeField:
+		return "DATETIME"
+	default:
+		return "TEXT"
+	}
+}
+
+func (d *SQLiteDriver) CreateTableSQL(m *model) string {
+	return createTableSQL(d, m, d.Quote("id")+" INTEGER PRIMARY KEY AUTOINCREMENT")
+}
+
+func (d *SQLiteDriver) AddColumnSQL(m *model, f Field) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", d.Quote(m.tableName), d.Quote(f.Attrs().columnName), d.ColumnType(f))
+}
+
+func (d *SQLiteDriver) Paginate(query string, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// PostgresDriver targets lib/pq, using $n placeholders and SERIAL ids.
+type PostgresDriver struct{}
+
+func (d *PostgresDriver) Open(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+func (d *PostgresDriver) Quote(ident string) string {
+	return `"` + ident + `"`
+}
+
+func (d *PostgresDriver) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d *PostgresDriver) ColumnType(f Field) string {
+	switch f.(type) {
+	case *IntField:
+		return "INTEGER"
+	case *FloatField:
+		return "DOUBLE PRECISION"
+	case *TimeField:
+		return "TIMESTAMPTZ"
+	default:
+		return "TEXT"
+	}
+}
+
+func (d *PostgresDriver) CreateTableSQL(m *model) string {
+	return createTableSQL(d, m, d.Quote("id")+" SERIAL PRIMARY KEY")
+}
+
+func (d *PostgresDriver) AddColumnSQL(m *model, f Field) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", d.Quote(m.tableName), d.Quote(f.Attrs().columnName), d.ColumnType(f))
+}
+
+func (d *PostgresDriver) Paginate(query string, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// MySQLDriver targets go-sql-driver/mysql, using backtick quoting and `?`
+// placeholders.
+type MySQLDriver struct{}
+
+func (d *MySQLDriver) Open(dsn string) (*sql.DB, error) {
+	return sql.Open("mysql", dsn)
+}
+
+func (d *MySQLDriver) Quote(ident string) string {
+	return "`" + ident + "`"
+}
+
+func (d *MySQLDriver) Placeholder(n int) string {
+	return "?"
+}
+
+func (d *MySQLDriver) ColumnType(f Field) string {
+	switch f.(type) {
+	case *IntField:
+		return "INT"
+	case *FloatField:
+		return "DOUBLE"
+	case *TimeField:
+		return "DATETIME"
+	default:
+		return "VARCHAR(255)"
+	}
+}
+
+func (d *MySQLDriver) CreateTableSQL(m *model) string {
+	return createTableSQL(d, m, d.Quote("id")+" INT AUTO_INCREMENT PRIMARY KEY")
+}
+
+func (d *MySQLDriver) AddColumnSQL(m *model, f Field) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", d.Quote(m.tableName), d.Quote(f.Attrs().columnName), d.ColumnType(f))
+}
+
+func (d *MySQLDriver) Paginate(query string, limit, offset int) string {
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", query, limit, offset)
+}
+
+// quoteColumns quotes each column name per a.Driver's dialect.
+func (a *Admin) quoteColumns(cols []string) []string {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = a.Driver.Quote(c)
+	}
+	return quoted
+}
+
+// placeholders returns n bound-parameter markers per a.Driver's dialect.
+func (a *Admin) placeholders(n int) []string {
+	ph := make([]string, n)
+	for i := 0; i < n; i++ {
+		ph[i] = a.Driver.Placeholder(i + 1)
+	}
+	return ph
+}
+
+// ensureMigrated runs AutoMigrate once, lazily, the first time a request is
+// handled — models are only registered after Setup returns, so migrating
+// inside Setup itself would see an empty model set.
+func (a *Admin) ensureMigrated() error {
+	if !a.AutoMigrate || a.migrated {
+		return nil
+	}
+
+	for _, m := range a.models {
+		if _, err := a.db.Exec(a.Driver.CreateTableSQL(m)); err != nil {
+			return err
+		}
+		for _, f := range m.fields {
+			// Best-effort: a column that already exists returns a
+			// dialect-specific error we don't try to distinguish.
+			a.db.Exec(a.Driver.AddColumnSQL(m, f))
+		}
+	}
+
+	a.migrated = true
+	return nil
+}
@@ -0,0 +1,224 @@
+package admin
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Field is implemented by every column type the admin knows how to render
+// and configure from a struct tag.
+type Field interface {
+	Attrs() *BaseField
+	Configure(tagMap map[string]string) error
+	Render(w io.Writer, val interface{}, err string)
+
+	// Marshal converts a value scanned from the database into the
+	// representation the JSON/GraphQL subsystems should serialize.
+	Marshal(val interface{}) interface{}
+
+	// Parse converts a textual cell (CSV or JSON string) into the typed
+	// value this field stores, the inverse of Marshal.
+	Parse(s string) (interface{}, error)
+
+	// Validate runs this field's validators (required/min/max/regex/unique
+	// and any custom ones) against a submitted value. row holds the rest
+	// of the submitted row, keyed by column name, for cross-field rules.
+	Validate(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error
+}
+
+// BaseField holds the bookkeeping shared by every Field implementation.
+// Concrete fields embed it and get Attrs() for free.
+type BaseField struct {
+	name       string
+	label      string
+	columnName string
+	list       bool
+
+	// filter marks this field as usable in REST ?<column>=<val> filtering.
+	filter bool
+
+	// refType is the Go type name a pointer (foreign-key) field refers to,
+	// used to resolve the field to the matching registered model in the
+	// GraphQL subsystem. Empty for non-foreign-key fields.
+	refType string
+
+	// roles gates which roles may read/write this field. Empty means
+	// unrestricted, preserving behavior for models that don't opt in.
+	roles roleRules
+
+	// validators run, in tag order, against a submitted value before it
+	// reaches the database.
+	validators []ValidatorFunc
+}
+
+// Validate runs every validator registered on this field via struct tags
+// or Admin.RegisterValidator, stopping at the first error. Every concrete
+// Field embeds *BaseField, so this implements Field.Validate for all of
+// them.
+func (b *BaseField) Validate(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+	for _, v := range b.validators {
+		if err := v(val, row, db, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BaseField) Attrs() *BaseField {
+	return b
+}
+
+// canRead reports whether role may see this field's value at all.
+func (b *BaseField) canRead(role string) bool {
+	return b.roles.can(role, false)
+}
+
+// canWrite reports whether role may set this field's value.
+func (b *BaseField) canWrite(role string) bool {
+	return b.roles.can(role, true)
+}
+
+// TextField renders a plain text input and stores its value as a string.
+type TextField struct {
+	*BaseField
+}
+
+func (f *TextField) Configure(tagMap map[string]string) error {
+	return nil
+}
+
+func (f *TextField) Render(w io.Writer, val interface{}, err string) {
+	fmt.Fprintf(w, `<input type="text" name="%s" value="%v">`, f.name, val)
+	if len(err) > 0 {
+		fmt.Fprintf(w, `<span class="error">%s</span>`, err)
+	}
+}
+
+func (f *TextField) Marshal(val interface{}) interface{} {
+	return fmt.Sprintf("%v", val)
+}
+
+func (f *TextField) Parse(s string) (interface{}, error) {
+	return s, nil
+}
+
+// IntField renders a number input and stores its value as an integer.
+type IntField struct {
+	*BaseField
+}
+
+func (f *IntField) Configure(tagMap map[string]string) error {
+	return nil
+}
+
+func (f *IntField) Render(w io.Writer, val interface{}, err string) {
+	fmt.Fprintf(w, `<input type="number" step="1" name="%s" value="%v">`, f.name, val)
+	if len(err) > 0 {
+		fmt.Fprintf(w, `<span class="error">%s</span>`, err)
+	}
+}
+
+func (f *IntField) Marshal(val interface{}) interface{} {
+	return val
+}
+
+func (f *IntField) Parse(s string) (interface{}, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+// FloatField renders a number input and stores its value as a float.
+type FloatField struct {
+	*BaseField
+}
+
+func (f *FloatField) Configure(tagMap map[string]string) error {
+	return nil
+}
+
+func (f *FloatField) Render(w io.Writer, val interface{}, err string) {
+	fmt.Fprintf(w, `<input type="number" step="any" name="%s" value="%v">`, f.name, val)
+	if len(err) > 0 {
+		fmt.Fprintf(w, `<span class="error">%s</span>`, err)
+	}
+}
+
+func (f *FloatField) Marshal(val interface{}) interface{} {
+	return val
+}
+
+func (f *FloatField) Parse(s string) (interface{}, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// TimeField renders a datetime-local input and stores its value as a time.Time.
+type TimeField struct {
+	*BaseField
+}
+
+func (f *TimeField) Configure(tagMap map[string]string) error {
+	return nil
+}
+
+func (f *TimeField) Render(w io.Writer, val interface{}, err string) {
+	fmt.Fprintf(w, `<input type="datetime-local" name="%s" value="%v">`, f.name, val)
+	if len(err) > 0 {
+		fmt.Fprintf(w, `<span class="error">%s</span>`, err)
+	}
+}
+
+// Marshal renders a time.Time value as RFC3339 so JSON/GraphQL clients get a
+// standard, timezone-aware timestamp. A value the driver scanned as anything
+// other than time.Time is formatted with %v and not converted to RFC3339.
+func (f *TimeField) Marshal(val interface{}) interface{} {
+	switch t := val.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case nil:
+		return nil
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// Parse accepts RFC3339 timestamps, matching what Marshal produces.
+func (f *TimeField) Parse(s string) (interface{}, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q, want RFC3339", s)
+	}
+	return t, nil
+}
+
+// URLField renders a url input and stores its value as a string.
+type URLField struct {
+	*BaseField
+}
+
+func (f *URLField) Configure(tagMap map[string]string) error {
+	return nil
+}
+
+func (f *URLField) Render(w io.Writer, val interface{}, err string) {
+	fmt.Fprintf(w, `<input type="url" name="%s" value="%v">`, f.name, val)
+	if len(err) > 0 {
+		fmt.Fprintf(w, `<span class="error">%s</span>`, err)
+	}
+}
+
+func (f *URLField) Marshal(val interface{}) interface{} {
+	return fmt.Sprintf("%v", val)
+}
+
+func (f *URLField) Parse(s string) (interface{}, error) {
+	return s, nil
+}
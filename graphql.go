@@ -0,0 +1,444 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+type contextKey string
+
+const ctxKeySession contextKey = "admin-session"
+
+func sessionFromContext(ctx context.Context) *session {
+	s, _ := ctx.Value(ctxKeySession).(*session)
+	return s
+}
+
+// handleGraphQL serves the GraphQL endpoint, lazily building the schema
+// from the registered models on first use.
+func (a *Admin) handleGraphQL(w http.ResponseWriter, r *http.Request, s *session) {
+	if a.graphqlSchema == nil {
+		schema, err := a.buildGraphQLSchema()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.graphqlSchema = schema
+	}
+
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         *a.graphqlSchema,
+		RequestString:  body.Query,
+		VariableValues: body.Variables,
+		Context:        context.WithValue(r.Context(), ctxKeySession, s),
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// graphqlScalarType maps a Field's concrete type to its GraphQL scalar,
+// mirroring the reflect.Kind switch RegisterModel uses to pick a Field.
+func graphqlScalarType(f Field) graphql.Output {
+	switch f.(type) {
+	case *TextField, *URLField:
+		return graphql.String
+	case *IntField:
+		return graphql.Int
+	case *FloatField:
+		return graphql.Float
+	case *TimeField:
+		return graphql.DateTime
+	default:
+		return nil
+	}
+}
+
+// buildGraphQLSchema walks admin.models and builds an object type per
+// model, a Query with <model>(id) / <model>s(limit, offset, filter), and a
+// Mutation with create/update/delete<Model>.
+func (a *Admin) buildGraphQLSchema() (*graphql.Schema, error) {
+	objects := map[string]*graphql.Object{}
+
+	for modelSlug, m := range a.models {
+		objects[modelSlug] = graphql.NewObject(graphql.ObjectConfig{
+			Name: m.Name,
+			Fields: graphql.Fields{
+				"id": &graphql.Field{Type: graphql.ID},
+			},
+		})
+	}
+
+	// Scalar fields, resolved straight off the row map built by fetchGraphQLRow.
+	for modelSlug, m := range a.models {
+		obj := objects[modelSlug]
+		for _, field := range m.fields {
+			attrs := field.Attrs()
+			if len(attrs.refType) > 0 {
+				continue
+			}
+			gt := graphqlScalarType(field)
+			if gt == nil {
+				continue
+			}
+			f := field
+			obj.AddFieldConfig(attrs.columnName, &graphql.Field{
+				Type: gt,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolveGraphQLField(p, f)
+				},
+			})
+		}
+	}
+
+	// Foreign-key fields become object references, added once every object
+	// type exists so forward references between models resolve.
+	for modelSlug, m := range a.models {
+		obj := objects[modelSlug]
+		for _, field := range m.fields {
+			attrs := field.Attrs()
+			if len(attrs.refType) == 0 {
+				continue
+			}
+			target := a.modelByTypeName(attrs.refType)
+			if target == nil {
+				continue
+			}
+			targetObj, ok := objects[target.Slug]
+			if !ok {
+				continue
+			}
+			f := field
+			refModel := target
+			obj.AddFieldConfig(strings.TrimSuffix(attrs.name, "Id"), &graphql.Field{
+				Type: targetObj,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return a.resolveGraphQLReference(p, f, refModel)
+				},
+			})
+		}
+		_ = modelSlug
+	}
+
+	queryFields := graphql.Fields{}
+	mutationFields := graphql.Fields{}
+
+	for modelSlug, m := range a.models {
+		obj := objects[modelSlug]
+		mdl := m
+		singular := strings.ToLower(mdl.Name)
+		plural := singular + "s"
+
+		queryFields[singular] = &graphql.Field{
+			Type: obj,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return a.resolveGraphQLGet(p, mdl)
+			},
+		}
+
+		queryFields[plural] = &graphql.Field{
+			Type: graphql.NewList(obj),
+			Args: graphql.FieldConfigArgument{
+				"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+				"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+				"filter": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return a.resolveGraphQLList(p, mdl)
+			},
+		}
+
+		mutationFields["create"+mdl.Name] = &graphql.Field{
+			Type: obj,
+			Args: graphqlInputArgs(mdl),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return a.resolveGraphQLCreate(p, mdl)
+			},
+		}
+
+		mutationFields["update"+mdl.Name] = &graphql.Field{
+			Type: obj,
+			Args: graphqlUpdateArgs(mdl),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return a.resolveGraphQLUpdate(p, mdl)
+			},
+		}
+
+		mutationFields["delete"+mdl.Name] = &graphql.Field{
+			Type: graphql.Boolean,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return a.resolveGraphQLDelete(p, mdl)
+			},
+		}
+		_ = modelSlug
+	}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:    graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: queryFields}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{Name: "Mutation", Fields: mutationFields}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// graphqlInputArgs builds mutation arguments for every column m exposes,
+// using ID for foreign-key columns and the field's own scalar otherwise.
+func graphqlInputArgs(m *model) graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{}
+	for _, field := range m.fields {
+		attrs := field.Attrs()
+		var t graphql.Input
+		if len(attrs.refType) > 0 {
+			t = graphql.ID
+		} else if gt := graphqlScalarType(field); gt != nil {
+			t = gt
+		} else {
+			continue
+		}
+		args[attrs.columnName] = &graphql.ArgumentConfig{Type: t}
+	}
+	return args
+}
+
+func graphqlUpdateArgs(m *model) graphql.FieldConfigArgument {
+	args := graphqlInputArgs(m)
+	args["id"] = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+	return args
+}
+
+// resolveGraphQLField reads a scalar column off the row map built by
+// fetchGraphQLRow/resolveGraphQLList, hiding it if role may not read it.
+func resolveGraphQLField(p graphql.ResolveParams, f Field) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !f.Attrs().canRead(s.Role) {
+		return nil, nil
+	}
+	row, ok := p.Source.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return row[f.Attrs().columnName], nil
+}
+
+// resolveGraphQLReference follows a foreign-key column to the referenced
+// model's row, gated by both the field's and the target model's roles.
+func (a *Admin) resolveGraphQLReference(p graphql.ResolveParams, f Field, target *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !f.Attrs().canRead(s.Role) || !target.canViewModel(s.Role) {
+		return nil, nil
+	}
+
+	row, ok := p.Source.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	fk := row[f.Attrs().columnName]
+	if fk == nil {
+		return nil, nil
+	}
+	return a.fetchGraphQLRow(target, fk)
+}
+
+// fetchGraphQLRow loads a single row of m by id into a column-name-keyed
+// map, with values run through each Field's Marshal so resolvers and the
+// REST subsystem agree on JSON representation.
+func (a *Admin) fetchGraphQLRow(m *model, id interface{}) (map[string]interface{}, error) {
+	columns := append([]string{"id"}, m.tableColumns()...)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1))
+	row := a.db.QueryRow(query, id)
+
+	dest := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+	if err := row.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{"id": dest[0]}
+	for i, field := range m.fields {
+		result[field.Attrs().columnName] = field.Marshal(dest[i+1])
+	}
+	return result, nil
+}
+
+func (a *Admin) resolveGraphQLGet(p graphql.ResolveParams, m *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !m.canViewModel(s.Role) {
+		return nil, fmt.Errorf("forbidden")
+	}
+	return a.fetchGraphQLRow(m, p.Args["id"])
+}
+
+func (a *Admin) resolveGraphQLList(p graphql.ResolveParams, m *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !m.canViewModel(s.Role) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	limit := defaultAPIPageSize
+	if v, ok := p.Args["limit"].(int); ok && v > 0 {
+		limit = v
+	}
+	offset := 0
+	if v, ok := p.Args["offset"].(int); ok && v >= 0 {
+		offset = v
+	}
+
+	where := []string{}
+	args := []interface{}{}
+	if raw, ok := p.Args["filter"].(string); ok && len(raw) > 0 {
+		filters := map[string]string{}
+		if err := json.Unmarshal([]byte(raw), &filters); err != nil {
+			return nil, err
+		}
+		for _, field := range m.fields {
+			attrs := field.Attrs()
+			if !attrs.filter {
+				continue
+			}
+			if val, ok := filters[attrs.columnName]; ok {
+				where = append(where, fmt.Sprintf("%s = %s", a.Driver.Quote(attrs.columnName), a.Driver.Placeholder(len(args)+1)))
+				args = append(args, val)
+			}
+		}
+	}
+
+	columns := append([]string{"id"}, m.tableColumns()...)
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName))
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query = a.Driver.Paginate(query, limit, offset)
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		dest := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := map[string]interface{}{"id": dest[0]}
+		for i, field := range m.fields {
+			row[field.Attrs().columnName] = field.Marshal(dest[i+1])
+		}
+		results = append(results, row)
+	}
+
+	return results, nil
+}
+
+func (a *Admin) resolveGraphQLCreate(p graphql.ResolveParams, m *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !m.canEditModel(s.Role) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	columns := []string{}
+	values := []interface{}{}
+	for _, field := range m.fields {
+		attrs := field.Attrs()
+		val, present := p.Args[attrs.columnName]
+		if !present {
+			continue
+		}
+		if !attrs.canWrite(s.Role) {
+			return nil, fmt.Errorf("role %q cannot write %q", s.Role, attrs.columnName)
+		}
+		columns = append(columns, attrs.columnName)
+		values = append(values, val)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", a.Driver.Quote(m.tableName), strings.Join(a.quoteColumns(columns), ", "), strings.Join(a.placeholders(len(columns)), ", "))
+	res, err := a.db.Exec(query, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return a.fetchGraphQLRow(m, id)
+}
+
+func (a *Admin) resolveGraphQLUpdate(p graphql.ResolveParams, m *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !m.canEditModel(s.Role) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	id := p.Args["id"]
+	columns := []string{}
+	values := []interface{}{}
+	for _, field := range m.fields {
+		attrs := field.Attrs()
+		val, present := p.Args[attrs.columnName]
+		if !present {
+			continue
+		}
+		if !attrs.canWrite(s.Role) {
+			return nil, fmt.Errorf("role %q cannot write %q", s.Role, attrs.columnName)
+		}
+		columns = append(columns, attrs.columnName)
+		values = append(values, val)
+	}
+
+	ph := a.placeholders(len(columns) + 1)
+	setClauses := make([]string, len(columns))
+	for i, c := range columns {
+		setClauses[i] = fmt.Sprintf("%s = %s", a.Driver.Quote(c), ph[i])
+	}
+	values = append(values, id)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", a.Driver.Quote(m.tableName), strings.Join(setClauses, ", "), a.Driver.Quote("id"), ph[len(columns)])
+	if _, err := a.db.Exec(query, values...); err != nil {
+		return nil, err
+	}
+	return a.fetchGraphQLRow(m, id)
+}
+
+func (a *Admin) resolveGraphQLDelete(p graphql.ResolveParams, m *model) (interface{}, error) {
+	s := sessionFromContext(p.Context)
+	if s == nil || !m.canEditModel(s.Role) {
+		return nil, fmt.Errorf("forbidden")
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s = %s", a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1))
+	if _, err := a.db.Exec(query, p.Args["id"]); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
@@ -0,0 +1,337 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// handlerWrapper authenticates the request, resolving a *session either
+// from the admin_session cookie or, on first contact, via HTTP Basic Auth
+// against Admin.UserProvider, before calling inner.
+func (a *Admin) handlerWrapper(inner func(w http.ResponseWriter, r *http.Request, s *session)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := a.currentSession(r)
+		if s == nil {
+			user, pass, ok := r.BasicAuth()
+			if ok {
+				if role, ok := a.UserProvider(user, pass); ok {
+					newSession, err := a.createSession(user, role)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					setSessionCookie(w, newSession)
+					s = newSession
+				}
+			}
+		}
+
+		if s == nil {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q`, a.Title))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := a.ensureMigrated(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		inner(w, r, s)
+	}
+}
+
+// handleIndex renders the front page, listing only the model groups and
+// models the current session's role may view.
+func (a *Admin) handleIndex(w http.ResponseWriter, r *http.Request, s *session) {
+	groups := []*modelGroup{}
+	for _, g := range a.modelGroups {
+		visible := &modelGroup{admin: a, Name: g.Name, slug: g.slug, Models: []*model{}}
+		for _, m := range g.Models {
+			if m.canViewModel(s.Role) {
+				visible.Models = append(visible.Models, m)
+			}
+		}
+		if len(visible.Models) > 0 {
+			groups = append(groups, visible)
+		}
+	}
+
+	data := struct {
+		Title  string
+		Groups []*modelGroup
+	}{a.Title, groups}
+
+	if err := templates.ExecuteTemplate(w, "index.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleLogout destroys the current session and clears its cookie.
+func (a *Admin) handleLogout(w http.ResponseWriter, r *http.Request, s *session) {
+	a.destroySession(s)
+	clearSessionCookie(w)
+	http.Redirect(w, r, a.Path+"/", http.StatusFound)
+}
+
+// handleList renders the list view for a model, restricted to the columns
+// the current role may read.
+func (a *Admin) handleList(w http.ResponseWriter, r *http.Request, s *session) {
+	if a.wantsJSON(r) {
+		a.apiList(w, r, s)
+		return
+	}
+
+	m, ok := a.models[mux.Vars(r)["slug"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !m.canViewModel(s.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	columns := []string{}
+	labels := []string{}
+	for _, f := range m.fields {
+		attrs := f.Attrs()
+		if !attrs.list || !attrs.canRead(s.Role) {
+			continue
+		}
+		columns = append(columns, attrs.columnName)
+		labels = append(labels, attrs.label)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName))
+	rows, err := a.db.Query(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	results := [][]interface{}{}
+	for rows.Next() {
+		dest := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		results = append(results, dest)
+	}
+
+	data := struct {
+		Model  *model
+		Labels []string
+		Rows   [][]interface{}
+	}{m, labels, results}
+
+	if err := templates.ExecuteTemplate(w, "list.html", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// fetchRowMap loads a single row of m by id into a column-name-keyed map,
+// for merging with posted values before validation. db is explicit so a
+// caller running inside a transaction (e.g. handleImport) can pass it
+// through and see that transaction's own uncommitted writes.
+func (a *Admin) fetchRowMap(db dbQueryer, m *model, id string) (map[string]interface{}, error) {
+	columns := m.tableColumns()
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1))
+	row := db.QueryRow(query, id)
+
+	dest := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(dest))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+	if err := row.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{"id": id}
+	for i, c := range columns {
+		result[c] = dest[i]
+	}
+	return result, nil
+}
+
+// formDataFor projects a column-name-keyed row back into the positional
+// slice renderFormForRole expects, aligned with m.fieldNames().
+func formDataFor(m *model, row map[string]interface{}) []interface{} {
+	data := make([]interface{}, len(m.fields))
+	for i, f := range m.fields {
+		data[i] = row[f.Attrs().columnName]
+	}
+	return data
+}
+
+// handleEdit renders the new/edit form (GET) and processes its submission
+// (POST), enforcing per-field RBAC in both directions.
+func (a *Admin) handleEdit(w http.ResponseWriter, r *http.Request, s *session) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if a.wantsJSON(r) {
+		switch {
+		case r.Method == "POST":
+			a.apiCreate(w, r, s)
+		case len(id) > 0:
+			a.apiGet(w, r, s)
+		default:
+			a.apiList(w, r, s)
+		}
+		return
+	}
+
+	m, ok := a.models[vars["slug"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !m.canViewModel(s.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == "POST" {
+		if !m.canEditModel(s.Role) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		row := map[string]interface{}{}
+		if len(id) > 0 {
+			existing, err := a.fetchRowMap(a.db, m, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			row = existing
+		}
+
+		columns := []string{}
+		values := []interface{}{}
+		for _, f := range m.fields {
+			attrs := f.Attrs()
+			if _, posted := r.PostForm[attrs.name]; !posted {
+				continue
+			}
+			if !attrs.canWrite(s.Role) {
+				http.Error(w, fmt.Sprintf("Forbidden: role %q cannot write %q", s.Role, attrs.name), http.StatusForbidden)
+				return
+			}
+			val := r.PostForm.Get(attrs.name)
+			row[attrs.columnName] = val
+			columns = append(columns, attrs.columnName)
+			values = append(values, val)
+		}
+
+		fieldErrs := map[string]string{}
+		for _, f := range m.fields {
+			attrs := f.Attrs()
+			val, present := row[attrs.columnName]
+			if !present {
+				continue
+			}
+			if err := f.Validate(val, row, a.db, m); err != nil {
+				fieldErrs[attrs.name] = err.Error()
+			}
+		}
+
+		var modelErr error
+		if len(fieldErrs) == 0 {
+			if rm, ok := m.instance.(validatedModel); ok {
+				modelErr = rm.Validate(m.buildInstance(row))
+			}
+		}
+
+		if len(fieldErrs) > 0 || modelErr != nil {
+			errs := make([]string, len(m.fieldNames()))
+			for i, name := range m.fieldNames() {
+				errs[i] = fieldErrs[name]
+			}
+
+			buf := &bytes.Buffer{}
+			if modelErr != nil {
+				fmt.Fprintf(buf, `<div class="error">%s</div>`, modelErr.Error())
+			}
+			m.renderFormForRole(buf, formDataFor(m, row), errs, s.Role)
+
+			tmplData := struct {
+				Model *model
+				Form  template.HTML
+			}{m, template.HTML(buf.String())}
+
+			if err := templates.ExecuteTemplate(w, "edit.html", tmplData); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		var query string
+		if len(id) > 0 {
+			ph := a.placeholders(len(columns) + 1)
+			setClauses := make([]string, len(columns))
+			for i, c := range columns {
+				setClauses[i] = fmt.Sprintf("%s = %s", a.Driver.Quote(c), ph[i])
+			}
+			values = append(values, id)
+			query = fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", a.Driver.Quote(m.tableName), strings.Join(setClauses, ", "), a.Driver.Quote("id"), ph[len(columns)])
+		} else {
+			ph := a.placeholders(len(columns))
+			query = fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", a.Driver.Quote(m.tableName), strings.Join(a.quoteColumns(columns), ", "), strings.Join(ph, ", "))
+		}
+
+		if _, err := a.db.Exec(query, values...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, a.modelURL(m.Slug, "/"), http.StatusFound)
+		return
+	}
+
+	data := []interface{}{}
+	if len(id) > 0 {
+		row := a.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(a.quoteColumns(m.tableColumns()), ", "), a.Driver.Quote(m.tableName), a.Driver.Quote("id"), a.Driver.Placeholder(1)), id)
+		dest := make([]interface{}, len(m.fields))
+		ptrs := make([]interface{}, len(dest))
+		for i := range dest {
+			ptrs[i] = &dest[i]
+		}
+		if err := row.Scan(ptrs...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data = dest
+	}
+
+	buf := &bytes.Buffer{}
+	m.renderFormForRole(buf, data, nil, s.Role)
+
+	tmplData := struct {
+		Model *model
+		Form  template.HTML
+	}{m, template.HTML(buf.String())}
+
+	if err := templates.ExecuteTemplate(w, "edit.html", tmplData); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,345 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// modelForExport resolves {slug} and checks that role may view the model,
+// writing the appropriate HTML-style error and returning ok=false otherwise.
+func (a *Admin) modelForExport(w http.ResponseWriter, r *http.Request, s *session) (m *model, ok bool) {
+	m, found := a.models[mux.Vars(r)["slug"]]
+	if !found {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if !m.canViewModel(s.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return m, true
+}
+
+// exportFields picks the columns an export should contain: every column
+// role may read with ?all=1, otherwise just the ones tagged `list`.
+func exportFields(r *http.Request, m *model, role string) []Field {
+	all := r.URL.Query().Get("all") == "1"
+	return apiFields(m, role, !all)
+}
+
+// handleExportCSV handles GET /model/{slug}/export.csv, streaming rows to
+// the response as they're scanned so large tables don't load into memory.
+func (a *Admin) handleExportCSV(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForExport(w, r, s)
+	if !ok {
+		return
+	}
+
+	fields := exportFields(r, m, s.Role)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Attrs().columnName
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName))
+	rows, err := a.db.Query(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, m.Slug))
+
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+
+	dest := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i, f := range fields {
+			record[i] = fmt.Sprintf("%v", f.Marshal(dest[i]))
+		}
+		cw.Write(record)
+		cw.Flush()
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleExportJSON handles GET /model/{slug}/export.json. Rows are written
+// as newline-delimited JSON objects rather than a single array, so
+// json.Encoder can stream each one to the client as it's scanned instead of
+// buffering the whole result set.
+func (a *Admin) handleExportJSON(w http.ResponseWriter, r *http.Request, s *session) {
+	m, ok := a.modelForExport(w, r, s)
+	if !ok {
+		return
+	}
+
+	fields := exportFields(r, m, s.Role)
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = f.Attrs().columnName
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(a.quoteColumns(columns), ", "), a.Driver.Quote(m.tableName))
+	rows, err := a.db.Query(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, m.Slug))
+
+	dest := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range dest {
+		ptrs[i] = &dest[i]
+	}
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := enc.Encode(marshalRow(fields, dest)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// decodeImportRows reads the request body as CSV or JSON (picked from
+// Content-Type, defaulting to CSV) into column-name-keyed rows. CSV cells
+// are kept as strings for Field.Parse; a JSON body's values are decoded as
+// whatever the array held. Absent or empty CSV cells are omitted so a row
+// only carries the columns it actually submits, matching handleEdit's
+// r.PostForm presence check.
+func decodeImportRows(r *http.Request) ([]map[string]interface{}, error) {
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		var rows []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %s", err)
+		}
+		return rows, nil
+	}
+
+	cr := csv.NewReader(r.Body)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV body: %s", err)
+	}
+
+	rows := []map[string]interface{}{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV body: %s", err)
+		}
+
+		row := map[string]interface{}{}
+		for i, col := range header {
+			if i < len(record) && len(record[i]) > 0 {
+				row[col] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importRowResult reports what happened to a single submitted row.
+type importRowResult struct {
+	Row    int      `json:"row"`
+	ID     string   `json:"id,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// writeImportRow updates the row at id when exists is true, or inserts a new
+// row otherwise, mirroring handleEdit's query-building for the same two
+// cases. exists is decided up front by the caller's own row lookup rather
+// than by inspecting RowsAffected after an UPDATE, since MySQL reports
+// RowsAffected as rows *changed*, not rows *matched* — an UPDATE that
+// matches an existing row but changes nothing would otherwise look
+// indistinguishable from "no such id" and get duplicated via INSERT.
+func (a *Admin) writeImportRow(tx *sql.Tx, m *model, id string, exists bool, row map[string]interface{}) error {
+	columns := []string{}
+	values := []interface{}{}
+	for _, c := range m.tableColumns() {
+		if v, ok := row[c]; ok {
+			columns = append(columns, c)
+			values = append(values, v)
+		}
+	}
+
+	if exists {
+		ph := a.placeholders(len(columns) + 1)
+		setClauses := make([]string, len(columns))
+		for i, c := range columns {
+			setClauses[i] = fmt.Sprintf("%s = %s", a.Driver.Quote(c), ph[i])
+		}
+		values = append(values, id)
+		query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", a.Driver.Quote(m.tableName), strings.Join(setClauses, ", "), a.Driver.Quote("id"), ph[len(columns)])
+		_, err := tx.Exec(query, values...)
+		return err
+	}
+
+	ph := a.placeholders(len(columns))
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", a.Driver.Quote(m.tableName), strings.Join(a.quoteColumns(columns), ", "), strings.Join(ph, ", "))
+	_, err := tx.Exec(query, values...)
+	return err
+}
+
+// handleImport handles POST /model/{slug}/import/. Every row is parsed and
+// run through the validation framework; ?dry=1 reports per-row parse and
+// validation errors without opening a transaction or writing anything, so
+// checks such as unique see only committed rows, not earlier rows of the
+// same batch, and database write errors are not reported. Otherwise all
+// rows are written in a single transaction, rolled back if any row fails.
+func (a *Admin) handleImport(w http.ResponseWriter, r *http.Request, s *session) {
+	vars := mux.Vars(r)
+	m, ok := a.models[vars["slug"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !m.canEditModel(s.Role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	rawRows, err := decodeImportRows(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry") == "1"
+
+	var tx *sql.Tx
+	batchDB := dbQueryer(a.db)
+	if !dryRun {
+		tx, err = a.db.Begin()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		batchDB = tx
+	}
+
+	results := make([]importRowResult, 0, len(rawRows))
+	failed := false
+
+	for i, cells := range rawRows {
+		res := importRowResult{Row: i + 1}
+
+		var id string
+		if v := cells["id"]; v != nil {
+			id = fmt.Sprintf("%v", v)
+		}
+		row := map[string]interface{}{}
+		exists := false
+		if len(id) > 0 {
+			if existing, err := a.fetchRowMap(batchDB, m, id); err == nil {
+				row = existing
+				exists = true
+			}
+			res.ID = id
+		}
+
+		for _, f := range m.fields {
+			attrs := f.Attrs()
+			raw, present := cells[attrs.columnName]
+			if !present {
+				continue
+			}
+			if !attrs.canWrite(s.Role) {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: role %q cannot write this column", attrs.name, s.Role))
+				continue
+			}
+			val, err := f.Parse(fmt.Sprintf("%v", raw))
+			if err != nil {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: %s", attrs.name, err.Error()))
+				continue
+			}
+			row[attrs.columnName] = val
+		}
+
+		for _, f := range m.fields {
+			attrs := f.Attrs()
+			val, present := row[attrs.columnName]
+			if !present {
+				continue
+			}
+			if err := f.Validate(val, row, batchDB, m); err != nil {
+				res.Errors = append(res.Errors, fmt.Sprintf("%s: %s", attrs.name, err.Error()))
+			}
+		}
+
+		if len(res.Errors) == 0 {
+			if rm, ok := m.instance.(validatedModel); ok {
+				if err := rm.Validate(m.buildInstance(row)); err != nil {
+					res.Errors = append(res.Errors, err.Error())
+				}
+			}
+		}
+
+		switch {
+		case len(res.Errors) > 0:
+			failed = true
+		case tx != nil:
+			if err := a.writeImportRow(tx, m, id, exists, row); err != nil {
+				res.Errors = append(res.Errors, err.Error())
+				failed = true
+			}
+		}
+
+		results = append(results, res)
+	}
+
+	if tx != nil {
+		if failed {
+			tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"dryRun":  dryRun,
+		"ok":      !failed,
+		"results": results,
+	})
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"github.com/extemporalgenome/slug"
 	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
 	_ "github.com/mattn/go-sqlite3"
 	"html/template"
 	"io"
+	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -28,11 +31,46 @@ type Admin struct {
 	Password string
 	sessions map[string]*session
 
-	db          *sql.DB
-	models      map[string]*model
-	modelGroups []*modelGroup
+	// Roles documents the valid role names for this admin and is
+	// informational only; enforcement comes from the `roles=` struct tag
+	// on fields and a model's AdminRoles method, evaluated against the
+	// session's Role.
+	Roles map[string]string
+
+	// UserProvider authenticates a username/password pair and returns the
+	// role to attach to the resulting session. When nil, Setup installs a
+	// default provider that checks Username/Password and grants SuperRole.
+	UserProvider func(username, password string) (role string, ok bool)
+
+	// Negotiator decides whether a request to an HTML route should instead
+	// be served as JSON. When nil, the Accept header is consulted.
+	Negotiator func(r *http.Request) bool
+
+	// EnableGraphQL mounts a GraphQL schema, generated from the registered
+	// models, at Path + "/graphql".
+	EnableGraphQL bool
+
+	// Driver builds the SQL dialect used for every generated query.
+	// Defaults to &SQLiteDriver{}, matching the admin's original behavior.
+	Driver Driver
+
+	// AutoMigrate, when true, creates tables and adds missing columns for
+	// every registered model the first time a request is handled.
+	AutoMigrate bool
+
+	db               *sql.DB
+	models           map[string]*model
+	modelGroups      []*modelGroup
+	graphqlSchema    *graphql.Schema
+	migrated         bool
+	customValidators map[string]ValidatorFunc
 }
 
+// SuperRole is the role granted by the default UserProvider. It bypasses
+// per-model and per-field roles= checks so existing single-user admins keep
+// working unchanged after adopting RBAC.
+const SuperRole = "*"
+
 // Setup registers page handlers and enables the admin.
 func Setup(admin *Admin) (*Admin, error) {
 	if len(admin.Title) == 0 {
@@ -43,9 +81,22 @@ func Setup(admin *Admin) (*Admin, error) {
 		return nil, errors.New("Username and/or password is missing")
 	}
 
+	if admin.UserProvider == nil {
+		admin.UserProvider = func(username, password string) (string, bool) {
+			if username == admin.Username && password == admin.Password {
+				return SuperRole, true
+			}
+			return "", false
+		}
+	}
+
+	if admin.Driver == nil {
+		admin.Driver = &SQLiteDriver{}
+	}
+
 	admin.sessions = map[string]*session{}
 
-	db, err := sql.Open("sqlite3", admin.Database)
+	db, err := admin.Driver.Open(admin.Database)
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +112,20 @@ func Setup(admin *Admin) (*Admin, error) {
 	sr.HandleFunc("/model/{slug}/", admin.handlerWrapper(admin.handleList))
 	sr.HandleFunc("/model/{slug}/new/", admin.handlerWrapper(admin.handleEdit))
 	sr.HandleFunc("/model/{slug}/edit/{id}/", admin.handlerWrapper(admin.handleEdit))
+	sr.HandleFunc("/model/{slug}/export.csv", admin.handlerWrapper(admin.handleExportCSV)).Methods("GET")
+	sr.HandleFunc("/model/{slug}/export.json", admin.handlerWrapper(admin.handleExportJSON)).Methods("GET")
+	sr.HandleFunc("/model/{slug}/import/", admin.handlerWrapper(admin.handleImport)).Methods("POST")
+
+	sr.HandleFunc("/api/model/{slug}/", admin.handlerWrapper(admin.apiList)).Methods("GET")
+	sr.HandleFunc("/api/model/{slug}/", admin.handlerWrapper(admin.apiCreate)).Methods("POST")
+	sr.HandleFunc("/api/model/{slug}/{id}/", admin.handlerWrapper(admin.apiGet)).Methods("GET")
+	sr.HandleFunc("/api/model/{slug}/{id}/", admin.handlerWrapper(admin.apiUpdate)).Methods("PUT")
+	sr.HandleFunc("/api/model/{slug}/{id}/", admin.handlerWrapper(admin.apiDelete)).Methods("DELETE")
+
+	if admin.EnableGraphQL {
+		sr.HandleFunc("/graphql", admin.handlerWrapper(admin.handleGraphQL))
+	}
+
 	return admin, nil
 }
 
@@ -121,6 +186,11 @@ func (g *modelGroup) RegisterModel(mdl interface{}) error {
 		tableName: tableName,
 		fields:    []Field{},
 		instance:  mdl,
+		admin:     g.admin,
+	}
+
+	if rm, ok := mdl.(rolesModel); ok {
+		am.roles = parseRoleRules(rm.AdminRoles())
 	}
 
 	for i := 0; i < ind.NumField(); i++ {
@@ -140,8 +210,10 @@ func (g *modelGroup) RegisterModel(mdl interface{}) error {
 
 		// Expect pointers to be foreignkeys and foreignkeys to have the form Field[Id]
 		fieldName := refl.Name
+		var refType string
 		if kind == reflect.Ptr {
 			fieldName += "Id"
+			refType = fieldType.Elem().Name()
 		}
 
 		// Transform struct keys to DB column names if needed
@@ -178,6 +250,7 @@ func (g *modelGroup) RegisterModel(mdl interface{}) error {
 			}
 		}
 		field.Attrs().name = fieldName
+		field.Attrs().refType = refType
 
 		// Read relevant config options from the tagMap
 		err = field.Configure(tagMap)
@@ -197,6 +270,54 @@ func (g *modelGroup) RegisterModel(mdl interface{}) error {
 			field.Attrs().list = true
 		}
 
+		if roles, ok := tagMap["roles"]; ok {
+			field.Attrs().roles = parseRoleRules(roles)
+		}
+
+		if _, ok := tagMap["filter"]; ok {
+			field.Attrs().filter = true
+		}
+
+		if _, ok := tagMap["required"]; ok {
+			field.Attrs().validators = append(field.Attrs().validators, requiredValidator())
+		}
+
+		if min, ok := tagMap["min"]; ok {
+			n, err := strconv.ParseFloat(min, 64)
+			if err != nil {
+				panic(err)
+			}
+			field.Attrs().validators = append(field.Attrs().validators, minValidator(n))
+		}
+
+		if max, ok := tagMap["max"]; ok {
+			n, err := strconv.ParseFloat(max, 64)
+			if err != nil {
+				panic(err)
+			}
+			field.Attrs().validators = append(field.Attrs().validators, maxValidator(n))
+		}
+
+		if pattern, ok := tagMap["regex"]; ok {
+			v, err := regexValidator(pattern)
+			if err != nil {
+				panic(err)
+			}
+			field.Attrs().validators = append(field.Attrs().validators, v)
+		}
+
+		if _, ok := tagMap["unique"]; ok {
+			field.Attrs().validators = append(field.Attrs().validators, uniqueValidator(tableField))
+		}
+
+		if validatorName, ok := tagMap["validate"]; ok {
+			v, ok := g.admin.customValidators[validatorName]
+			if !ok {
+				panic(fmt.Sprintf("admin: no validator registered under name %q", validatorName))
+			}
+			field.Attrs().validators = append(field.Attrs().validators, v)
+		}
+
 		am.fields = append(am.fields, field)
 	}
 
@@ -211,6 +332,14 @@ type model struct {
 	fields    []Field
 	tableName string
 	instance  interface{}
+
+	// roles gates GET/LIST/POST/PUT/DELETE for this model as a whole.
+	// An empty roles is unrestricted, same as a field's roles.
+	roles roleRules
+
+	// admin is the owning Admin, giving field validators (e.g. unique)
+	// access to the configured Driver.
+	admin *Admin
 }
 
 func (m *model) renderForm(w io.Writer, data []interface{}, errors []string) {
@@ -229,6 +358,33 @@ func (m *model) renderForm(w io.Writer, data []interface{}, errors []string) {
 	}
 }
 
+// renderFormForRole renders the same fields as renderForm, but hides fields
+// role may not read and disables fields role may read but not write.
+func (m *model) renderFormForRole(w io.Writer, data []interface{}, errors []string, role string) {
+	hasData := len(data) == len(m.fieldNames())
+	var val interface{}
+	for i, fieldName := range m.fieldNames() {
+		field := m.fieldByName(fieldName)
+		if !field.Attrs().canRead(role) {
+			continue
+		}
+
+		if hasData {
+			val = data[i]
+		}
+		var err string
+		if errors != nil {
+			err = errors[i]
+		}
+
+		if field.Attrs().canWrite(role) {
+			field.Render(w, val, err)
+		} else {
+			fmt.Fprintf(w, `<input type="text" name="%s" value="%v" disabled>`, field.Attrs().name, val)
+		}
+	}
+}
+
 func (m *model) fieldNames() []string {
 	names := []string{}
 	for _, field := range m.fields {
@@ -276,6 +432,67 @@ func (m *model) fieldByName(name string) Field {
 	return nil
 }
 
+// buildInstance reflects a fresh *T (T being this model's registered type)
+// populated from values, for passing to a validatedModel's Validate hook.
+// Only scalar-settable struct fields are populated; unrecognized or
+// unparsable values are left at their zero value.
+func (m *model) buildInstance(values map[string]interface{}) interface{} {
+	t := reflect.TypeOf(m.instance)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	ptr := reflect.New(t)
+	elem := ptr.Elem()
+
+	for _, field := range m.fields {
+		val, ok := values[field.Attrs().columnName]
+		if !ok {
+			continue
+		}
+
+		sf := elem.FieldByName(strings.TrimSuffix(field.Attrs().name, "Id"))
+		if !sf.IsValid() || !sf.CanSet() {
+			continue
+		}
+
+		str := fmt.Sprintf("%v", val)
+		switch sf.Kind() {
+		case reflect.String:
+			sf.SetString(str)
+		case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+				sf.SetInt(n)
+			}
+		case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseUint(str, 10, 64); err == nil {
+				sf.SetUint(n)
+			}
+		case reflect.Float32, reflect.Float64:
+			if n, err := strconv.ParseFloat(str, 64); err == nil {
+				sf.SetFloat(n)
+			}
+		}
+	}
+
+	return ptr.Interface()
+}
+
+// modelByTypeName finds a registered model whose instance is of the given
+// Go type name, used to resolve foreign-key fields in the GraphQL subsystem.
+func (a *Admin) modelByTypeName(name string) *model {
+	for _, m := range a.models {
+		t := reflect.TypeOf(m.instance)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
 func (a *Admin) modelURL(slug, action string) string {
 	if _, ok := a.models[slug]; !ok {
 		return a.Path
@@ -0,0 +1,90 @@
+package admin
+
+import "strings"
+
+// permSet is the read/write permission a single role has been granted for a
+// model or field.
+type permSet struct {
+	read  bool
+	write bool
+}
+
+func parsePerm(s string) permSet {
+	s = strings.ToUpper(s)
+	return permSet{
+		read:  strings.Contains(s, "R"),
+		write: strings.Contains(s, "W"),
+	}
+}
+
+// roleRules maps a role name to the permission it holds, parsed from a
+// struct tag like `roles=APP_ADMIN:RW,AUDITOR:R`. A nil/empty roleRules
+// means the model or field is unrestricted, which keeps existing admins
+// working unchanged.
+type roleRules map[string]permSet
+
+func parseRoleRules(tag string) roleRules {
+	rules := roleRules{}
+	if len(tag) == 0 {
+		return rules
+	}
+
+	for _, entry := range strings.Split(tag, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		kv := strings.SplitN(entry, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		rules[strings.TrimSpace(kv[0])] = parsePerm(kv[1])
+	}
+
+	return rules
+}
+
+// can reports whether role has the requested permission. An empty rule set
+// is unrestricted; an unlisted role has no access.
+func (r roleRules) can(role string, write bool) bool {
+	if role == SuperRole || len(r) == 0 {
+		return true
+	}
+
+	perm, ok := r[role]
+	if !ok {
+		return false
+	}
+	if write {
+		return perm.write
+	}
+	return perm.read
+}
+
+// rolesModel lets a registered model declare its own RBAC rules, analogous
+// to namedModel for display names.
+type rolesModel interface {
+	AdminRoles() string
+}
+
+// canViewModel reports whether role may see m at all (GET/LIST).
+func (m *model) canViewModel(role string) bool {
+	return m.roles.can(role, false)
+}
+
+// canEditModel reports whether role may create/update/delete rows of m.
+func (m *model) canEditModel(role string) bool {
+	return m.roles.can(role, true)
+}
+
+// visibleFields returns the fields of m that role is allowed to see,
+// preserving field order.
+func (m *model) visibleFields(role string) []Field {
+	fields := []Field{}
+	for _, f := range m.fields {
+		if f.Attrs().canRead(role) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
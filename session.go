@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+const sessionCookieName = "admin_session"
+const sessionLifetime = 24 * time.Hour
+
+// session represents a logged-in admin user. Role drives the RBAC checks
+// in the list/edit handlers.
+type session struct {
+	id       string
+	Username string
+	Role     string
+	expires  time.Time
+}
+
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createSession starts a new session for username/role and stores it on the Admin.
+func (a *Admin) createSession(username, role string) (*session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &session{
+		id:       id,
+		Username: username,
+		Role:     role,
+		expires:  time.Now().Add(sessionLifetime),
+	}
+	a.sessions[id] = s
+	return s, nil
+}
+
+// currentSession looks up the session for the request's cookie, if any.
+func (a *Admin) currentSession(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
+	}
+
+	s, ok := a.sessions[cookie.Value]
+	if !ok || time.Now().After(s.expires) {
+		return nil
+	}
+	return s
+}
+
+func (a *Admin) destroySession(s *session) {
+	delete(a.sessions, s.id)
+}
+
+func setSessionCookie(w http.ResponseWriter, s *session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    s.id,
+		Path:     "/",
+		Expires:  s.expires,
+		HttpOnly: true,
+	})
+}
+
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    sessionCookieName,
+		Value:   "",
+		Path:    "/",
+		Expires: time.Unix(0, 0),
+	})
+}
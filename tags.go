@@ -0,0 +1,41 @@
+package admin
+
+import "strings"
+
+// rolesTagPrefix is the option name whose value is itself a comma-separated
+// list (role:perm pairs), so it can't go through the generic comma split
+// below without losing every role past the first.
+const rolesTagPrefix = "roles="
+
+// parseTag splits an `admin:"..."` struct tag into its key=value options.
+// A bare option (no `=`) is stored with an empty value, e.g. "list" -> "".
+// The roles= option is special-cased: it must be the last option in the tag,
+// since its value runs to the end of the tag and is never itself comma-split
+// (`roles=APP_ADMIN:RW,AUDITOR:R` uses commas to separate roles, not options).
+func parseTag(tag string) (map[string]string, error) {
+	tagMap := map[string]string{}
+	if len(tag) == 0 {
+		return tagMap, nil
+	}
+
+	if i := strings.Index(tag, rolesTagPrefix); i >= 0 {
+		tagMap["roles"] = strings.TrimSpace(tag[i+len(rolesTagPrefix):])
+		tag = tag[:i]
+	}
+
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) == 2 {
+			tagMap[key] = strings.TrimSpace(kv[1])
+		} else {
+			tagMap[key] = ""
+		}
+	}
+
+	return tagMap, nil
+}
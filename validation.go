@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// dbQueryer is the subset of *sql.DB and *sql.Tx that validators need for
+// SELECT-based checks like uniqueness, so a caller running inside a
+// transaction (e.g. handleImport) can pass the *sql.Tx itself and have the
+// check see that transaction's own uncommitted writes.
+type dbQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ValidatorFunc is a single validation rule for a submitted value. row
+// holds the rest of the submitted row (and "id", if editing), keyed by
+// column name, for cross-field or uniqueness rules; m gives access to the
+// table name and its owning Admin's Driver.
+type ValidatorFunc func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error
+
+// validatedModel lets a registered model declare cross-field validation
+// beyond what per-field tags can express, analogous to namedModel for
+// display names.
+type validatedModel interface {
+	Validate(candidate interface{}) error
+}
+
+// RegisterValidator makes fn available to struct tags as `validate=name`.
+func (a *Admin) RegisterValidator(name string, fn func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error) {
+	if a.customValidators == nil {
+		a.customValidators = map[string]ValidatorFunc{}
+	}
+	a.customValidators[name] = ValidatorFunc(fn)
+}
+
+func requiredValidator() ValidatorFunc {
+	return func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+		if val == nil || fmt.Sprintf("%v", val) == "" {
+			return errors.New("is required")
+		}
+		return nil
+	}
+}
+
+func minValidator(min float64) ValidatorFunc {
+	return func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+		if n, ok := numericValue(val); ok {
+			if n < min {
+				return fmt.Errorf("must be at least %v", min)
+			}
+			return nil
+		}
+		if float64(len(fmt.Sprintf("%v", val))) < min {
+			return fmt.Errorf("must be at least %v characters", min)
+		}
+		return nil
+	}
+}
+
+func maxValidator(max float64) ValidatorFunc {
+	return func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+		if n, ok := numericValue(val); ok {
+			if n > max {
+				return fmt.Errorf("must be at most %v", max)
+			}
+			return nil
+		}
+		if float64(len(fmt.Sprintf("%v", val))) > max {
+			return fmt.Errorf("must be at most %v characters", max)
+		}
+		return nil
+	}
+}
+
+func numericValue(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func regexValidator(pattern string) (ValidatorFunc, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+		if !re.MatchString(fmt.Sprintf("%v", val)) {
+			return fmt.Errorf("does not match %s", pattern)
+		}
+		return nil
+	}, nil
+}
+
+// uniqueValidator is the case-insensitive uniqueness check: no other row
+// may share val in columnName, compared with LOWER() and excluding the row
+// being edited (row["id"]).
+func uniqueValidator(columnName string) ValidatorFunc {
+	return func(val interface{}, row map[string]interface{}, db dbQueryer, m *model) error {
+		driver := m.admin.Driver
+		id := row["id"]
+		if id == nil {
+			id = 0
+		}
+
+		query := fmt.Sprintf(
+			"SELECT COUNT(*) FROM %s WHERE LOWER(%s) = LOWER(%s) AND %s <> %s",
+			driver.Quote(m.tableName), driver.Quote(columnName), driver.Placeholder(1), driver.Quote("id"), driver.Placeholder(2),
+		)
+
+		var count int
+		if err := db.QueryRow(query, val, id).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("is already in use")
+		}
+		return nil
+	}
+}